@@ -1,11 +1,16 @@
 package main
 
 import (
+	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	mr "mapreduce/internal"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"plugin"
+	"strings"
 	"time"
 )
 
@@ -14,6 +19,9 @@ const (
 	workerArg      = "mrworker"
 	minArgs        = 3
 	sleepDuration  = time.Second
+	nameSymbol     = "Name"
+	pluginGlob     = "*.so"
+	defaultNReduce = 10
 )
 
 func main() {
@@ -24,30 +32,218 @@ func main() {
 
 func run() error {
 	if len(os.Args) < minArgs {
-		return fmt.Errorf("Usage: go run main.go [mrcoordinator|mrworker] [plugin_file] [input_files...]")
+		return fmt.Errorf("Usage: go run main.go [mrcoordinator|mrworker] [plugin_file|--plugin-dir dir|--exec executable|--pipeline manifest.json] [input_files...]")
 	}
 
 	switch os.Args[1] {
 	case coordinatorArg:
-		return runCoordinator()
+		return runCoordinator(os.Args[2:])
 	case workerArg:
-		return runWorker()
+		return runWorker(os.Args[2:])
 	default:
 		return fmt.Errorf("Invalid argument: %s. Use 'mrcoordinator' or 'mrworker'", os.Args[1])
 	}
 }
 
-func runWorker() error {
-	mapf, reducef, err := loadPlugin(os.Args[2])
+// runWorker's calls below assume mr.Worker takes a single MapReducer
+// argument rather than the separate mapf/reducef pair it historically
+// took; that signature change lives in the shared mapreduce package
+// (outside this chunk of the tree) and isn't visible here.
+func runWorker(args []string) error {
+	fs := flag.NewFlagSet(workerArg, flag.ContinueOnError)
+	pluginDir := fs.String("plugin-dir", "", "directory of plugin .so files to auto-discover")
+	job := fs.String("job", "", "job name to run when --plugin-dir discovers more than one plugin")
+	exec := fs.String("exec", "", "path to an external map/reduce executable speaking the subprocess RPC protocol")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *exec != "" {
+		mapReducer, err := mr.NewSubprocessMapReducer(*exec)
+		if err != nil {
+			return err
+		}
+		defer mapReducer.Close()
+		mr.Worker(mapReducer)
+		return nil
+	}
+
+	if *pluginDir != "" {
+		reg := mr.NewPluginRegistry()
+		jobs, err := discoverPlugins(*pluginDir, reg)
+		if err != nil {
+			return err
+		}
+
+		name := *job
+		if name == "" {
+			// A bare worker process still speaks the single-job Worker
+			// protocol, so it can only run one job at a time; require
+			// --job to disambiguate once the directory holds more than
+			// one plugin.
+			if len(jobs) != 1 {
+				return fmt.Errorf("--plugin-dir %s found %d plugins (%s); pass --job to pick one", *pluginDir, len(jobs), strings.Join(jobNames(jobs), ", "))
+			}
+			for only := range jobs {
+				name = only
+			}
+		}
+
+		entry, ok := jobs[name]
+		if !ok {
+			return fmt.Errorf("--plugin-dir %s: no plugin registered for job %q", *pluginDir, name)
+		}
+		mapReducer, err := reg.MapReducer(entry.id)
+		if err != nil {
+			return err
+		}
+		mr.Worker(mapReducer)
+		return nil
+	}
+
+	rest := fs.Args()
+	if len(rest) < 1 {
+		return fmt.Errorf("Usage: go run main.go mrworker [plugin_file|--plugin-dir dir|--exec executable]")
+	}
+	mapf, reducef, combinef, partitionf, err := loadPluginWithExtras(rest[0])
 	if err != nil {
 		return err
 	}
-	mr.Worker(mapf, reducef)
+	mr.Worker(mr.NewFuncMapReducerWithExtras(mapf, reducef, combinef, partitionf))
+	return nil
+}
+
+func runCoordinator(args []string) error {
+	fs := flag.NewFlagSet(coordinatorArg, flag.ContinueOnError)
+	pipelinePath := fs.String("pipeline", "", "path to a pipeline manifest listing jobs to run sequentially, each with its own auto-managed worker")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *pipelinePath != "" {
+		return runPipeline(*pipelinePath)
+	}
+
+	return waitForCoordinator(mr.MakeCoordinator(fs.Args(), defaultNReduce))
+}
+
+// PipelineStage describes one job in a pipeline manifest: which plugin to
+// run, which files feed it, and how many reduce partitions to use.
+type PipelineStage struct {
+	Job        string   `json:"job"`
+	InputGlobs []string `json:"input_globs"`
+	Reducers   int      `json:"reducers"`
+}
+
+// Pipeline is a manifest of stages to run sequentially against the
+// coordinator, each stage's output feeding the next stage's input_globs.
+// runPipeline itself spawns and tears down the worker process for each
+// stage (re-execing this binary as `mrworker --plugin-dir PluginDir --job
+// stage.Job`), so an operator running a pipeline never has to manually
+// restart or repoint a worker between stages.
+type Pipeline struct {
+	PluginDir string          `json:"plugin_dir"`
+	Stages    []PipelineStage `json:"stages"`
+}
+
+func runPipeline(manifestPath string) error {
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return fmt.Errorf("cannot read pipeline manifest %v: %w", manifestPath, err)
+	}
+
+	var pipeline Pipeline
+	if err := json.Unmarshal(data, &pipeline); err != nil {
+		return fmt.Errorf("cannot parse pipeline manifest %v: %w", manifestPath, err)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot locate this binary to run per-stage workers: %w", err)
+	}
+
+	reg := mr.NewPluginRegistry()
+	jobs, err := discoverPlugins(pipeline.PluginDir, reg)
+	if err != nil {
+		return err
+	}
+
+	for i, stage := range pipeline.Stages {
+		entry, ok := jobs[stage.Job]
+		if !ok {
+			return fmt.Errorf("pipeline stage %d: no plugin registered for job %q", i, stage.Job)
+		}
+
+		inputs, err := expandGlobs(stage.InputGlobs)
+		if err != nil {
+			return fmt.Errorf("pipeline stage %d (%s): %w", i, stage.Job, err)
+		}
+
+		nReduce := stage.Reducers
+		if nReduce <= 0 {
+			nReduce = defaultNReduce
+		}
+
+		worker, err := startStageWorker(exe, pipeline.PluginDir, stage.Job)
+		if err != nil {
+			return fmt.Errorf("pipeline stage %d (%s): %w", i, stage.Job, err)
+		}
+
+		log.Printf("pipeline: stage %d (%s) over %d input file(s), worker pid %d running %s", i, stage.Job, len(inputs), worker.Process.Pid, entry.path)
+		runErr := waitForCoordinator(mr.MakeCoordinator(inputs, nReduce))
+		stopStageWorker(worker)
+		if runErr != nil {
+			return fmt.Errorf("pipeline stage %d (%s): %w", i, stage.Job, runErr)
+		}
+	}
+
 	return nil
 }
 
-func runCoordinator() error {
-	m := mr.MakeCoordinator(os.Args[2:], 10)
+// startStageWorker re-execs exe as a `mrworker --plugin-dir pluginDir --job
+// job` subprocess, so runPipeline can hand each stage a worker already
+// pointed at the right plugin without the operator restarting anything.
+func startStageWorker(exe, pluginDir, job string) (*exec.Cmd, error) {
+	cmd := exec.Command(exe, workerArg, "--plugin-dir", pluginDir, "--job", job)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("cannot start worker for job %q: %w", job, err)
+	}
+	return cmd, nil
+}
+
+// stopStageWorker tears down a worker started by startStageWorker once its
+// stage's coordinator is done, so the next stage doesn't race it for tasks.
+func stopStageWorker(cmd *exec.Cmd) {
+	if cmd.Process != nil {
+		cmd.Process.Kill()
+	}
+	cmd.Wait()
+}
+
+// expandGlobs resolves a set of shell-style globs into a deduplicated,
+// ordered list of matching filenames so one stage's mr-out-* files can
+// feed the next stage's input_globs.
+func expandGlobs(globs []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var files []string
+	for _, pattern := range globs {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %w", pattern, err)
+		}
+		for _, m := range matches {
+			if !seen[m] {
+				seen[m] = true
+				files = append(files, m)
+			}
+		}
+	}
+	return files, nil
+}
+
+func waitForCoordinator(m *mr.Coordinator) error {
 	for !m.Done() {
 		time.Sleep(sleepDuration)
 	}
@@ -55,23 +251,121 @@ func runCoordinator() error {
 	return nil
 }
 
+// pluginEntry is a plugin discovered by discoverPlugins: the PluginID it
+// was registered under, plus the path it was loaded from so callers can
+// tell an operator which file a job name maps to.
+type pluginEntry struct {
+	id   mr.PluginID
+	path string
+}
+
+// discoverPlugins walks dir non-recursively for *.so files and eagerly
+// Loads every one of them into reg up front, keying the resulting
+// PluginID by the job name exported via its Name symbol (falling back to
+// the file's basename, without extension, when a plugin doesn't export
+// one). This calls reg.Load for every plugin in dir regardless of which
+// job a worker actually ends up running: reg loads a plugin only through
+// Load with its path, and no task in this tree carries a PluginID for a
+// worker to load against (see runWorker and runPipeline).
+func discoverPlugins(dir string, reg *mr.PluginRegistry) (map[string]pluginEntry, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, pluginGlob))
+	if err != nil {
+		return nil, fmt.Errorf("cannot scan plugin dir %v: %w", dir, err)
+	}
+
+	jobs := make(map[string]pluginEntry, len(matches))
+	for _, path := range matches {
+		name, err := pluginJobName(path)
+		if err != nil {
+			return nil, err
+		}
+
+		id, err := reg.Load(path)
+		if err != nil {
+			return nil, err
+		}
+
+		jobs[name] = pluginEntry{id: id, path: path}
+	}
+
+	return jobs, nil
+}
+
+// jobNames returns jobs' keys for an error message listing the available
+// job names.
+func jobNames(jobs map[string]pluginEntry) []string {
+	names := make([]string, 0, len(jobs))
+	for name := range jobs {
+		names = append(names, name)
+	}
+	return names
+}
+
+// pluginJobName opens path to read its optional Name symbol. discoverPlugins
+// then passes the same path to reg.Load, whose plugin.Open returns the
+// plugin already loaded here rather than loading it a second time.
+func pluginJobName(path string) (string, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("cannot load plugin %v: %w", path, err)
+	}
+
+	if symbol, err := p.Lookup(nameSymbol); err == nil {
+		if name, ok := symbol.(*string); ok {
+			return *name, nil
+		}
+	}
+
+	base := filepath.Base(path)
+	return strings.TrimSuffix(base, filepath.Ext(base)), nil
+}
+
 func loadPlugin(filename string) (func(string, string) []mr.KeyValue, func(string, []string) string, error) {
+	mapf, reducef, _, _, err := loadPluginWithExtras(filename)
+	return mapf, reducef, err
+}
+
+// loadPluginWithExtras loads filename's required Map/Reduce symbols plus
+// its optional Combine and Partition symbols. combinef and/or partitionf
+// are nil when the plugin doesn't export them, so existing wc.go-style
+// plugins that only define Map and Reduce continue to work unchanged.
+func loadPluginWithExtras(filename string) (
+	mapf func(string, string) []mr.KeyValue,
+	reducef func(string, []string) string,
+	combinef func(string, []string) string,
+	partitionf func(string, int) int,
+	err error,
+) {
 	p, err := plugin.Open(filename)
 	if err != nil {
-		return nil, nil, fmt.Errorf("cannot load plugin %v: %w", filename, err)
+		return nil, nil, nil, nil, fmt.Errorf("cannot load plugin %v: %w", filename, err)
 	}
 
-	mapf, err := lookupPluginFunc(p, "Map")
+	mapSymbol, err := lookupPluginFunc(p, "Map")
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, nil, err
 	}
 
-	reducef, err := lookupPluginFunc(p, "Reduce")
+	reduceSymbol, err := lookupPluginFunc(p, "Reduce")
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, nil, err
+	}
+
+	mapf = mapSymbol.(func(string, string) []mr.KeyValue)
+	reducef = reduceSymbol.(func(string, []string) string)
+
+	if symbol, err := p.Lookup("Combine"); err == nil {
+		if fn, ok := symbol.(func(string, []string) string); ok {
+			combinef = fn
+		}
+	}
+	if symbol, err := p.Lookup("Partition"); err == nil {
+		if fn, ok := symbol.(func(string, int) int); ok {
+			partitionf = fn
+		}
 	}
 
-	return mapf.(func(string, string) []mr.KeyValue), reducef.(func(string, []string) string), nil
+	return mapf, reducef, combinef, partitionf, nil
 }
 
 func lookupPluginFunc(p *plugin.Plugin, funcName string) (plugin.Symbol, error) {
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestExpandGlobs_DedupesAndPreservesOrder(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt", "c.log"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := expandGlobs([]string{filepath.Join(dir, "*.txt"), filepath.Join(dir, "a.txt")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")}
+	if len(files) != len(want) {
+		t.Fatalf("expandGlobs = %v, want %v", files, want)
+	}
+	for i := range want {
+		if files[i] != want[i] {
+			t.Fatalf("expandGlobs = %v, want %v", files, want)
+		}
+	}
+}
+
+func TestExpandGlobs_InvalidPattern(t *testing.T) {
+	if _, err := expandGlobs([]string{"["}); err == nil {
+		t.Fatal("expected an error for an invalid glob pattern")
+	}
+}
+
+func TestExpandGlobs_NoMatches(t *testing.T) {
+	dir := t.TempDir()
+	files, err := expandGlobs([]string{filepath.Join(dir, "*.missing")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 0 {
+		t.Fatalf("expandGlobs with no matches = %v, want empty", files)
+	}
+}
+
+func TestJobNames(t *testing.T) {
+	jobs := map[string]pluginEntry{
+		"wordcount": {path: "/plugins/wc.so"},
+		"topk":      {path: "/plugins/topk.so"},
+	}
+
+	names := jobNames(jobs)
+	sort.Strings(names)
+
+	want := []string{"topk", "wordcount"}
+	if len(names) != len(want) || names[0] != want[0] || names[1] != want[1] {
+		t.Fatalf("jobNames = %v, want %v", names, want)
+	}
+}
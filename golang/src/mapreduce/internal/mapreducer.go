@@ -0,0 +1,116 @@
+package internal
+
+// MapReducer is the interface a worker drives to execute a job's map and
+// reduce functions, whether they run in-process (a Go plugin) or out of
+// process (any language speaking the subprocess RPC protocol).
+type MapReducer interface {
+	Map(filename, contents string) []KeyValue
+	Reduce(key string, values []string) string
+}
+
+// Combiner is implemented by a MapReducer whose plugin exports an optional
+// Combine symbol. When present, a worker runs it locally over map output
+// before writing intermediate files, shrinking shuffle I/O for associative
+// reducers such as word count.
+type Combiner interface {
+	Combine(key string, values []string) string
+}
+
+// Partitioner is implemented by a MapReducer whose plugin exports an
+// optional Partition symbol, replacing the default ihash(key) % nReduce
+// with e.g. range- or locality-aware partitioning.
+type Partitioner interface {
+	Partition(key string, nReduce int) int
+}
+
+// funcMapReducer adapts a plain map/reduce function pair, such as the ones
+// loaded from a Go plugin, to the MapReducer interface.
+type funcMapReducer struct {
+	mapf    func(string, string) []KeyValue
+	reducef func(string, []string) string
+}
+
+// NewFuncMapReducer wraps an in-process map/reduce function pair as a
+// MapReducer.
+func NewFuncMapReducer(mapf func(string, string) []KeyValue, reducef func(string, []string) string) MapReducer {
+	return &funcMapReducer{mapf: mapf, reducef: reducef}
+}
+
+func (f *funcMapReducer) Map(filename, contents string) []KeyValue {
+	return f.mapf(filename, contents)
+}
+
+func (f *funcMapReducer) Reduce(key string, values []string) string {
+	return f.reducef(key, values)
+}
+
+// combinerFunc and partitionerFunc adapt bare functions to Combiner and
+// Partitioner, mirroring the http.HandlerFunc pattern.
+type combinerFunc func(string, []string) string
+
+func (c combinerFunc) Combine(key string, values []string) string { return c(key, values) }
+
+type partitionerFunc func(string, int) int
+
+func (p partitionerFunc) Partition(key string, nReduce int) int { return p(key, nReduce) }
+
+// NewFuncMapReducerWithExtras wraps an in-process map/reduce function pair
+// as a MapReducer, additionally implementing Combiner and/or Partitioner
+// when combinef and/or partitionf are non-nil. Both are strictly optional:
+// the returned value only satisfies Combiner/Partitioner via a type
+// assertion when the corresponding function was actually supplied, so
+// existing wc.go-style plugins that only define Map and Reduce continue
+// to work unchanged.
+func NewFuncMapReducerWithExtras(
+	mapf func(string, string) []KeyValue,
+	reducef func(string, []string) string,
+	combinef func(string, []string) string,
+	partitionf func(string, int) int,
+) MapReducer {
+	base := &funcMapReducer{mapf: mapf, reducef: reducef}
+
+	switch {
+	case combinef != nil && partitionf != nil:
+		return struct {
+			MapReducer
+			Combiner
+			Partitioner
+		}{base, combinerFunc(combinef), partitionerFunc(partitionf)}
+	case combinef != nil:
+		return struct {
+			MapReducer
+			Combiner
+		}{base, combinerFunc(combinef)}
+	case partitionf != nil:
+		return struct {
+			MapReducer
+			Partitioner
+		}{base, partitionerFunc(partitionf)}
+	default:
+		return base
+	}
+}
+
+// ApplyCombiner runs mr's Combine over values if mr implements Combiner,
+// returning ok=false when it doesn't so the caller knows to write values
+// through to intermediate files unchanged. mr.Worker calls this after Map
+// and before partitioning, per the Combiner doc comment above.
+func ApplyCombiner(mr MapReducer, key string, values []string) (result string, ok bool) {
+	c, ok := mr.(Combiner)
+	if !ok {
+		return "", false
+	}
+	return c.Combine(key, values), true
+}
+
+// ApplyPartitioner runs mr's Partition if mr implements Partitioner,
+// returning ok=false when it doesn't so the caller falls back to the
+// default ihash(key) % nReduce. mr.Worker calls this to assign each
+// intermediate key-value pair to a reduce bucket.
+func ApplyPartitioner(mr MapReducer, key string, nReduce int) (partition int, ok bool) {
+	p, ok := mr.(Partitioner)
+	if !ok {
+		return 0, false
+	}
+	return p.Partition(key, nReduce), true
+}
@@ -0,0 +1,52 @@
+package internal
+
+import "testing"
+
+func wcMapf(filename, contents string) []KeyValue  { return nil }
+func wcReducef(key string, values []string) string { return values[0] }
+
+func TestNewFuncMapReducerWithExtras_NoOptionalSymbols(t *testing.T) {
+	mr := NewFuncMapReducerWithExtras(wcMapf, wcReducef, nil, nil)
+
+	if _, ok := mr.(Combiner); ok {
+		t.Fatal("expected no Combiner when combinef is nil")
+	}
+	if _, ok := mr.(Partitioner); ok {
+		t.Fatal("expected no Partitioner when partitionf is nil")
+	}
+	if _, ok := ApplyCombiner(mr, "k", []string{"1"}); ok {
+		t.Fatal("ApplyCombiner should report ok=false without a Combine symbol")
+	}
+	if _, ok := ApplyPartitioner(mr, "k", 10); ok {
+		t.Fatal("ApplyPartitioner should report ok=false without a Partition symbol")
+	}
+}
+
+func TestNewFuncMapReducerWithExtras_BothOptionalSymbols(t *testing.T) {
+	combinef := func(key string, values []string) string { return "combined:" + key }
+	partitionf := func(key string, nReduce int) int { return 7 % nReduce }
+
+	mr := NewFuncMapReducerWithExtras(wcMapf, wcReducef, combinef, partitionf)
+
+	result, ok := ApplyCombiner(mr, "word", []string{"1", "1"})
+	if !ok || result != "combined:word" {
+		t.Fatalf("ApplyCombiner = %q, %v; want \"combined:word\", true", result, ok)
+	}
+
+	partition, ok := ApplyPartitioner(mr, "word", 3)
+	if !ok || partition != 1 {
+		t.Fatalf("ApplyPartitioner = %d, %v; want 1, true", partition, ok)
+	}
+}
+
+func TestNewFuncMapReducerWithExtras_OnlyCombiner(t *testing.T) {
+	combinef := func(key string, values []string) string { return "c" }
+	mr := NewFuncMapReducerWithExtras(wcMapf, wcReducef, combinef, nil)
+
+	if _, ok := ApplyCombiner(mr, "k", nil); !ok {
+		t.Fatal("expected Combiner to be present")
+	}
+	if _, ok := ApplyPartitioner(mr, "k", 10); ok {
+		t.Fatal("expected no Partitioner when partitionf is nil")
+	}
+}
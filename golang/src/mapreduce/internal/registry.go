@@ -0,0 +1,182 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"plugin"
+	"sync"
+)
+
+const pluginNameSymbol = "PluginName"
+
+// PluginID identifies a loaded plugin by a hash of its path combined with
+// its exported PluginName symbol, so two distinct plugins that happen to
+// export identically-named Map/Reduce symbols never collide once loaded
+// into the same registry.
+type PluginID string
+
+// loadedPlugin caches the symbols a single plugin exports, so a task that
+// references it by PluginID doesn't pay for a repeated plugin.Open/Lookup.
+type loadedPlugin struct {
+	path        string
+	name        string
+	mapFn       func(string, string) []KeyValue
+	reduceFn    func(string, []string) string
+	combineFn   func(string, []string) string // optional; nil if the plugin doesn't export Combine
+	partitionFn func(string, int) int         // optional; nil if the plugin doesn't export Partition
+}
+
+// PluginRegistry loads and caches plugins keyed by PluginID. A plugin is
+// loaded only when Load is called with its path; MapReducer, Combine and
+// Partition serve plugins already loaded and do not load one by PluginID.
+type PluginRegistry struct {
+	mu     sync.Mutex
+	loaded map[PluginID]*loadedPlugin
+}
+
+// NewPluginRegistry returns an empty registry.
+func NewPluginRegistry() *PluginRegistry {
+	return &PluginRegistry{loaded: make(map[PluginID]*loadedPlugin)}
+}
+
+// Load opens path, registers it under the resulting PluginID if it isn't
+// already cached there, and returns that ID. Calling Load again with the
+// same path calls plugin.Open again (which returns the already-loaded
+// plugin) to recompute the ID, and returns the cached entry without
+// looking up its Map/Reduce/Combine/Partition symbols again.
+func (r *PluginRegistry) Load(path string) (PluginID, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("cannot load plugin %v: %w", path, err)
+	}
+
+	name := ""
+	if symbol, err := p.Lookup(pluginNameSymbol); err == nil {
+		if namePtr, ok := symbol.(*string); ok {
+			name = *namePtr
+		}
+	}
+	id := pluginID(path, name)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.loaded[id]; ok {
+		return id, nil
+	}
+
+	mapFn, err := lookupMapFunc(p)
+	if err != nil {
+		return "", err
+	}
+	reduceFn, err := lookupReduceFunc(p)
+	if err != nil {
+		return "", err
+	}
+
+	r.loaded[id] = &loadedPlugin{
+		path:        path,
+		name:        name,
+		mapFn:       mapFn,
+		reduceFn:    reduceFn,
+		combineFn:   lookupCombineFunc(p),
+		partitionFn: lookupPartitionFunc(p),
+	}
+	return id, nil
+}
+
+// pluginID hashes path and name together so plugins loaded from distinct
+// paths (or exporting distinct PluginName symbols from the same build)
+// never collide, while re-loading the same path/name pair is idempotent.
+func pluginID(path, name string) PluginID {
+	sum := sha256.Sum256([]byte(path + "\x00" + name))
+	return PluginID(hex.EncodeToString(sum[:8]))
+}
+
+// MapReducer builds a MapReducer for the plugin previously registered
+// under id, so a worker can dispatch a task tagged with a PluginID without
+// knowing anything else about the plugin.
+func (r *PluginRegistry) MapReducer(id PluginID) (MapReducer, error) {
+	entry, ok := r.get(id)
+	if !ok {
+		return nil, fmt.Errorf("no plugin loaded for id %v", id)
+	}
+	return NewFuncMapReducerWithExtras(entry.mapFn, entry.reduceFn, entry.combineFn, entry.partitionFn), nil
+}
+
+// Combine returns the plugin's optional Combine function, if any.
+func (r *PluginRegistry) Combine(id PluginID) (func(string, []string) string, bool) {
+	entry, ok := r.get(id)
+	if !ok || entry.combineFn == nil {
+		return nil, false
+	}
+	return entry.combineFn, true
+}
+
+// Partition returns the plugin's optional Partition function, if any.
+func (r *PluginRegistry) Partition(id PluginID) (func(string, int) int, bool) {
+	entry, ok := r.get(id)
+	if !ok || entry.partitionFn == nil {
+		return nil, false
+	}
+	return entry.partitionFn, true
+}
+
+func (r *PluginRegistry) get(id PluginID) (*loadedPlugin, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry, ok := r.loaded[id]
+	return entry, ok
+}
+
+func lookupMapFunc(p *plugin.Plugin) (func(string, string) []KeyValue, error) {
+	symbol, err := p.Lookup("Map")
+	if err != nil {
+		return nil, fmt.Errorf("cannot find Map in plugin: %w", err)
+	}
+	mapf, ok := symbol.(func(string, string) []KeyValue)
+	if !ok {
+		return nil, fmt.Errorf("plugin Map has unexpected signature")
+	}
+	return mapf, nil
+}
+
+func lookupReduceFunc(p *plugin.Plugin) (func(string, []string) string, error) {
+	symbol, err := p.Lookup("Reduce")
+	if err != nil {
+		return nil, fmt.Errorf("cannot find Reduce in plugin: %w", err)
+	}
+	reducef, ok := symbol.(func(string, []string) string)
+	if !ok {
+		return nil, fmt.Errorf("plugin Reduce has unexpected signature")
+	}
+	return reducef, nil
+}
+
+// lookupCombineFunc returns the plugin's Combine symbol, or nil if it
+// doesn't export one; Combine is strictly optional.
+func lookupCombineFunc(p *plugin.Plugin) func(string, []string) string {
+	symbol, err := p.Lookup("Combine")
+	if err != nil {
+		return nil
+	}
+	combinef, ok := symbol.(func(string, []string) string)
+	if !ok {
+		return nil
+	}
+	return combinef
+}
+
+// lookupPartitionFunc returns the plugin's Partition symbol, or nil if it
+// doesn't export one; Partition is strictly optional.
+func lookupPartitionFunc(p *plugin.Plugin) func(string, int) int {
+	symbol, err := p.Lookup("Partition")
+	if err != nil {
+		return nil
+	}
+	partitionf, ok := symbol.(func(string, int) int)
+	if !ok {
+		return nil
+	}
+	return partitionf
+}
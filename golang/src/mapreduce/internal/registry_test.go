@@ -0,0 +1,33 @@
+package internal
+
+import "testing"
+
+func TestPluginID_DeterministicAndUnique(t *testing.T) {
+	a := pluginID("/plugins/wc.so", "wordcount")
+	b := pluginID("/plugins/wc.so", "wordcount")
+	if a != b {
+		t.Fatalf("pluginID not deterministic: %v != %v", a, b)
+	}
+
+	if c := pluginID("/plugins/wc.so", "topk"); a == c {
+		t.Fatal("expected distinct PluginIDs for the same path with different PluginName symbols")
+	}
+
+	if d := pluginID("/plugins/topk.so", "wordcount"); a == d {
+		t.Fatal("expected distinct PluginIDs for distinct paths with the same PluginName symbol")
+	}
+}
+
+func TestPluginRegistry_UnknownID(t *testing.T) {
+	reg := NewPluginRegistry()
+
+	if _, err := reg.MapReducer("does-not-exist"); err == nil {
+		t.Fatal("expected an error for an unregistered PluginID")
+	}
+	if _, ok := reg.Combine("does-not-exist"); ok {
+		t.Fatal("expected ok=false from Combine for an unregistered PluginID")
+	}
+	if _, ok := reg.Partition("does-not-exist"); ok {
+		t.Fatal("expected ok=false from Partition for an unregistered PluginID")
+	}
+}
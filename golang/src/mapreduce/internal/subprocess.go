@@ -0,0 +1,180 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"net/rpc/jsonrpc"
+	"os/exec"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	heartbeatInterval = 2 * time.Second
+	heartbeatMisses   = 3
+)
+
+// pipeConn combines a subprocess's stdout and stdin into the single
+// io.ReadWriteCloser that net/rpc/jsonrpc requires as a transport.
+type pipeConn struct {
+	io.ReadCloser
+	io.WriteCloser
+}
+
+func (p *pipeConn) Close() error {
+	rerr := p.ReadCloser.Close()
+	werr := p.WriteCloser.Close()
+	if rerr != nil {
+		return rerr
+	}
+	return werr
+}
+
+// MapArgs and MapReply carry a Worker.Map call across the subprocess RPC
+// protocol.
+type MapArgs struct {
+	Filename string
+	Contents string
+}
+
+type MapReply struct {
+	KeyValues []KeyValue
+}
+
+// ReduceArgs and ReduceReply carry a Worker.Reduce call across the
+// subprocess RPC protocol.
+type ReduceArgs struct {
+	Key    string
+	Values []string
+}
+
+type ReduceReply struct {
+	Value string
+}
+
+// PingArgs and PingReply implement the heartbeat that lets a worker detect
+// a hung subprocess without waiting on the coordinator's task timeout.
+type PingArgs struct{}
+type PingReply struct{}
+
+// SubprocessMapReducer implements MapReducer by driving an external process
+// over a stdio JSON-RPC connection, so map/reduce functions can be written
+// in any language that can speak line-delimited JSON-RPC on stdin/stdout.
+// The process must expose a "Worker" service with Map, Reduce and Ping
+// methods matching the Args/Reply types above.
+type SubprocessMapReducer struct {
+	cmd    *exec.Cmd
+	client rpcClient
+	dead   atomic.Bool
+	done   chan struct{}
+}
+
+// rpcClient is the subset of *rpc.Client SubprocessMapReducer depends on,
+// so tests can substitute a fake without shelling out to a real process.
+type rpcClient interface {
+	Call(serviceMethod string, args, reply interface{}) error
+	Close() error
+}
+
+// NewSubprocessMapReducer starts path as a subprocess and wires its
+// stdin/stdout as a JSON-RPC transport. It begins heartbeating the
+// subprocess immediately; after heartbeatMisses consecutive failed pings,
+// Healthy reports false so the worker can stop handing it new tasks. Map
+// and Reduce still issue their RPC normally regardless of dead, so a hung
+// external process leaves the call blocked rather than faking a result,
+// and the coordinator's existing task timeout is what actually recovers it.
+func NewSubprocessMapReducer(path string, args ...string) (*SubprocessMapReducer, error) {
+	cmd := exec.Command(path, args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("cannot open stdin pipe to %v: %w", path, err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("cannot open stdout pipe to %v: %w", path, err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("cannot start subprocess worker %v: %w", path, err)
+	}
+
+	client := jsonrpc.NewClient(&pipeConn{ReadCloser: stdout, WriteCloser: stdin})
+
+	s := &SubprocessMapReducer{
+		cmd:    cmd,
+		client: client,
+		done:   make(chan struct{}),
+	}
+	go s.heartbeat()
+	return s, nil
+}
+
+func (s *SubprocessMapReducer) heartbeat() {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+
+	misses := 0
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			// A hung process never answers, so a ping that outlasts
+			// heartbeatInterval counts as a miss rather than blocking here.
+			pong := make(chan error, 1)
+			go func() { pong <- s.client.Call("Worker.Ping", &PingArgs{}, &PingReply{}) }()
+			var err error
+			select {
+			case err = <-pong:
+			case <-time.After(heartbeatInterval):
+				err = fmt.Errorf("ping timed out after %v", heartbeatInterval)
+			case <-s.done:
+				return
+			}
+			if err != nil {
+				misses++
+				if misses >= heartbeatMisses {
+					s.dead.Store(true)
+				}
+				continue
+			}
+			misses = 0
+		}
+	}
+}
+
+func (s *SubprocessMapReducer) Map(filename, contents string) []KeyValue {
+	reply := MapReply{}
+	if err := s.client.Call("Worker.Map", &MapArgs{Filename: filename, Contents: contents}, &reply); err != nil {
+		return nil
+	}
+	return reply.KeyValues
+}
+
+func (s *SubprocessMapReducer) Reduce(key string, values []string) string {
+	reply := ReduceReply{}
+	if err := s.client.Call("Worker.Reduce", &ReduceArgs{Key: key, Values: values}, &reply); err != nil {
+		return ""
+	}
+	return reply.Value
+}
+
+// Healthy reports false once heartbeatMisses consecutive Pings have failed
+// or timed out, and stays false from then on. A worker should check this before handing
+// the subprocess a new task, but should NOT use it to cut short a Map or
+// Reduce call already in flight; the coordinator's task timeout handles
+// that.
+func (s *SubprocessMapReducer) Healthy() bool {
+	return !s.dead.Load()
+}
+
+// Close stops the heartbeat, tears down the RPC connection and waits for
+// the subprocess to exit. If the subprocess was already detected dead, its
+// Wait would otherwise block forever, so Close kills it first.
+func (s *SubprocessMapReducer) Close() error {
+	close(s.done)
+	s.client.Close()
+	if s.dead.Load() && s.cmd.Process != nil {
+		s.cmd.Process.Kill()
+	}
+	return s.cmd.Wait()
+}
@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+// fakeRPCClient lets tests drive SubprocessMapReducer without a real
+// subprocess by substituting for rpcClient.
+type fakeRPCClient struct {
+	mu      sync.Mutex
+	callErr error
+	calls   int
+}
+
+func (f *fakeRPCClient) Call(serviceMethod string, args, reply interface{}) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	return f.callErr
+}
+
+func (f *fakeRPCClient) Close() error { return nil }
+
+func TestSubprocessMapReducer_HealthyByDefault(t *testing.T) {
+	s := &SubprocessMapReducer{client: &fakeRPCClient{}, done: make(chan struct{})}
+	if !s.Healthy() {
+		t.Fatal("expected a freshly constructed SubprocessMapReducer to be healthy")
+	}
+}
+
+func TestSubprocessMapReducer_DeadDoesNotShortCircuitCalls(t *testing.T) {
+	fake := &fakeRPCClient{}
+	s := &SubprocessMapReducer{client: fake, done: make(chan struct{})}
+	s.dead.Store(true)
+
+	if s.Healthy() {
+		t.Fatal("expected Healthy to report false once dead")
+	}
+
+	s.Map("file", "contents")
+	s.Reduce("key", []string{"a", "b"})
+
+	if fake.calls != 2 {
+		t.Fatalf("expected Map/Reduce to still issue their RPC even when dead, got %d calls", fake.calls)
+	}
+}
+
+func TestSubprocessMapReducer_CallErrorYieldsZeroValue(t *testing.T) {
+	fake := &fakeRPCClient{callErr: errors.New("connection reset")}
+	s := &SubprocessMapReducer{client: fake, done: make(chan struct{})}
+
+	if kvs := s.Map("file", "contents"); kvs != nil {
+		t.Fatalf("expected nil KeyValues on RPC error, got %v", kvs)
+	}
+	if v := s.Reduce("key", []string{"a"}); v != "" {
+		t.Fatalf("expected empty Value on RPC error, got %q", v)
+	}
+}